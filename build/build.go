@@ -0,0 +1,242 @@
+// Package build is a small redo-style DAG scheduler for the runner's own
+// pipeline (coverage analysis, per-response tests, result writing, docx
+// generation): each Target names the other targets it depends on and, when
+// it runs, reports every file it read through the record callback passed to
+// Build. The Scheduler keeps one recfile of those inputs per target (path,
+// size, mtime, sha256, goredo-style) and skips re-running a target when
+// every recorded input is still unchanged.
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Target is one node in the build graph.
+type Target interface {
+	// Name uniquely identifies the target; its recfile is stored as
+	// "<name>.rec" under the Scheduler's directory.
+	Name() string
+	// Deps lists the names of targets that must run (or be found
+	// up-to-date) before this one builds.
+	Deps() []string
+	// Build does the target's work, calling record for every input file
+	// it reads so the Scheduler can detect staleness next time without
+	// re-running the target.
+	Build(record func(path string)) error
+}
+
+// Scheduler runs a DAG of Targets, skipping any target whose previously
+// recorded inputs are all still unchanged.
+type Scheduler struct {
+	dir   string
+	force bool
+
+	mu      sync.Mutex
+	once    map[string]*sync.Once
+	errs    map[string]error
+	targets map[string]Target
+}
+
+// NewScheduler creates a Scheduler whose recfiles live under dir. force
+// makes every target rebuild regardless of its recfile, mirroring the
+// -force flag's effect on the per-response cache.
+func NewScheduler(dir string, force bool) *Scheduler {
+	return &Scheduler{
+		dir:     dir,
+		force:   force,
+		once:    map[string]*sync.Once{},
+		errs:    map[string]error{},
+		targets: map[string]Target{},
+	}
+}
+
+// Register adds targets to the graph, keyed by Name().
+func (s *Scheduler) Register(targets ...Target) {
+	for _, t := range targets {
+		s.targets[t.Name()] = t
+	}
+}
+
+// Run builds every named target and, transitively, its dependencies.
+// Independent subtrees run concurrently; each target builds at most once
+// per Scheduler even if reached through multiple paths.
+func (s *Scheduler) Run(names ...string) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(names))
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			errs[i] = s.run(name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) run(name string) error {
+	s.mu.Lock()
+	once, ok := s.once[name]
+	if !ok {
+		once = &sync.Once{}
+		s.once[name] = once
+	}
+	s.mu.Unlock()
+
+	once.Do(func() {
+		err := s.runOnce(name)
+		s.mu.Lock()
+		s.errs[name] = err
+		s.mu.Unlock()
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errs[name]
+}
+
+func (s *Scheduler) runOnce(name string) error {
+	t, ok := s.targets[name]
+	if !ok {
+		return fmt.Errorf("build: unknown target %q", name)
+	}
+
+	var wg sync.WaitGroup
+	depErrs := make([]error, len(t.Deps()))
+	for i, dep := range t.Deps() {
+		wg.Add(1)
+		go func(i int, dep string) {
+			defer wg.Done()
+			depErrs[i] = s.run(dep)
+		}(i, dep)
+	}
+	wg.Wait()
+	for i, err := range depErrs {
+		if err != nil {
+			return fmt.Errorf("build: dependency %q of %q failed: %w", t.Deps()[i], name, err)
+		}
+	}
+
+	recFile := filepath.Join(s.dir, name+".rec")
+	if !s.force {
+		if unchanged, err := recordedInputsUnchanged(recFile); err == nil && unchanged {
+			fmt.Printf("build: %s up to date\n", name)
+			return nil
+		}
+	}
+
+	var (
+		inputs   []string
+		inputsMu sync.Mutex
+	)
+	record := func(path string) {
+		inputsMu.Lock()
+		defer inputsMu.Unlock()
+		inputs = append(inputs, path)
+	}
+
+	if err := t.Build(record); err != nil {
+		return err
+	}
+	return writeInputRecord(recFile, inputs)
+}
+
+func fileFingerprint(path string) (size, mtime int64, hash string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	size = info.Size()
+	mtime = info.ModTime().Unix()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return 0, 0, "", err
+	}
+	return size, mtime, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeInputRecord(recFile string, inputs []string) error {
+	if len(inputs) == 0 {
+		// A target that recorded nothing can never be verified unchanged
+		// (recordedInputsUnchanged would vacuously return true for an
+		// empty recfile), so leave no recfile behind: the next run sees
+		// it as never having built and runs Build again, instead of
+		// treating "recorded zero inputs" as "permanently up to date".
+		if err := os.Remove(recFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("build: could not remove stale %s: %w", recFile, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(recFile), 0755); err != nil {
+		return fmt.Errorf("build: could not create %s: %w", filepath.Dir(recFile), err)
+	}
+
+	var b strings.Builder
+	for _, path := range inputs {
+		size, mtime, hash, err := fileFingerprint(path)
+		if err != nil {
+			// Input vanished after Build read it; nothing useful to
+			// record, so leave it out and let the next run see it as
+			// never having been recorded.
+			continue
+		}
+		fmt.Fprintf(&b, "Path: %s\nSize: %d\nMtime: %d\nHash: %s\n\n", path, size, mtime, hash)
+	}
+	return os.WriteFile(recFile, []byte(b.String()), 0644)
+}
+
+func recordedInputsUnchanged(recFile string) (bool, error) {
+	data, err := os.ReadFile(recFile)
+	if err != nil {
+		return false, err
+	}
+
+	for _, stanza := range strings.Split(string(data), "\n\n") {
+		stanza = strings.TrimSpace(stanza)
+		if stanza == "" {
+			continue
+		}
+
+		rec := map[string]string{}
+		for _, line := range strings.Split(stanza, "\n") {
+			key, value, ok := strings.Cut(line, ": ")
+			if !ok {
+				continue
+			}
+			rec[key] = value
+		}
+
+		size, mtime, hash, err := fileFingerprint(rec["Path"])
+		if err != nil {
+			return false, nil
+		}
+		wantSize, _ := strconv.ParseInt(rec["Size"], 10, 64)
+		wantMtime, _ := strconv.ParseInt(rec["Mtime"], 10, 64)
+		if size != wantSize || mtime != wantMtime || hash != rec["Hash"] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
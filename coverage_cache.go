@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mainCoverageEnvVars lists the environment variables consulted when
+// compiling and running main.go's coverage analysis. A change to any of
+// these can change the compiled binary or its behavior, so they are part of
+// the cache key alongside the files below.
+var mainCoverageEnvVars = []string{"GOFLAGS", "GOEXPERIMENT", "CGO_ENABLED", "PATH"}
+
+// goListPackage mirrors the subset of `go list -deps -json` fields needed to
+// find the .go files belonging to the module under test.
+type goListPackage struct {
+	Dir      string   `json:"Dir"`
+	GoFiles  []string `json:"GoFiles"`
+	Standard bool     `json:"Standard"`
+	Module   *struct {
+		Dir  string
+		Main bool
+	} `json:"Module"`
+}
+
+// moduleDepFiles returns the .go files (as paths under each package's Dir,
+// as `go list` reports it) belonging to packages that `go list -deps -json
+// ./...` reports as part of the main module, skipping the standard library
+// and packages of any other module. Best-effort: if `go list` is unavailable or fails, it returns an
+// empty slice rather than failing the whole cache computation.
+func moduleDepFiles(taskDir string) []string {
+	cmd := exec.Command("go", "list", "-deps", "-json", "./...")
+	cmd.Dir = taskDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	decoder := json.NewDecoder(strings.NewReader(string(output)))
+	for decoder.More() {
+		var pkg goListPackage
+		if err := decoder.Decode(&pkg); err != nil {
+			break
+		}
+		if pkg.Standard || pkg.Module == nil || !pkg.Module.Main {
+			continue
+		}
+		for _, goFile := range pkg.GoFiles {
+			files = append(files, filepath.Join(pkg.Dir, goFile))
+		}
+	}
+	return files
+}
+
+// buildMainCoverageInputLog builds the recfile-style input log for the
+// main.go coverage cache: one "F <path> <sha256>" line per dependency file
+// (main.go, main_test.go, go.mod, go.sum, and every .go file reported by
+// `go list -deps -json ./...` as belonging to the module) and one
+// "E <NAME>=<sha256>" line per consulted env var. The log is returned sorted
+// so its hash is stable across runs regardless of enumeration order.
+func buildMainCoverageInputLog(taskDir string) ([]string, error) {
+	var lines []string
+
+	depFiles := []string{
+		filepath.Join(taskDir, "main.go"),
+		filepath.Join(taskDir, "main_test.go"),
+		filepath.Join(taskDir, "go.mod"),
+		filepath.Join(taskDir, "go.sum"),
+	}
+	depFiles = append(depFiles, moduleDepFiles(taskDir)...)
+
+	for _, path := range depFiles {
+		hash, err := calculateFileHash(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error hashing %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(taskDir, path)
+		if err != nil {
+			rel = path
+		}
+		lines = append(lines, fmt.Sprintf("F %s %s", rel, hash))
+	}
+
+	for _, name := range mainCoverageEnvVars {
+		valueHash := sha256.Sum256([]byte(os.Getenv(name)))
+		lines = append(lines, fmt.Sprintf("E %s=%s", name, hex.EncodeToString(valueHash[:])))
+	}
+
+	sort.Strings(lines)
+	return lines, nil
+}
+
+// mainCoverageCacheKey hashes the sorted input log into a single hex digest
+// suitable for use as part of a cache filename.
+func mainCoverageCacheKey(log []string) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(strings.Join(log, "\n")))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"bigcodebench-auto/launcher"
+)
+
+// headlessMode is set once in main() and, when true, makes runHeadlessTest
+// and runHeadlessMainTest additionally stream headlessEvent JSON lines to
+// stdout for CI consumption.
+var headlessMode bool
+
+// interactiveMode is set once in main() from -launcher=terminal (or its
+// deprecated -interactive alias) and, when true, restores the original
+// terminal/screenshot path via activeLauncher. The default (false) runs
+// `go test` in-process with no terminal window.
+var interactiveMode bool
+
+// activeLauncher is the launcher.Launcher chosen by -launcher in main(),
+// used by openTerminalAndRunTest, openTerminalAndRunMainTest, and
+// killProcessesByTitlePrefix.
+var activeLauncher launcher.Launcher
+
+// isHeadless reports whether the runner should skip terminals, screenshots,
+// and notifications: either because -headless was passed, CI=true is set,
+// or (on Linux) there's no DISPLAY to open a terminal on.
+func isHeadless(flagValue bool) bool {
+	if flagValue {
+		return true
+	}
+	if strings.EqualFold(os.Getenv("CI"), "true") {
+		return true
+	}
+	if runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" {
+		return true
+	}
+	return false
+}
+
+// headlessEvent is one line of the structured event stream written to
+// stdout in headless mode.
+type headlessEvent struct {
+	Time           string  `json:"time"`
+	Action         string  `json:"action"`
+	Name           string  `json:"name"`
+	LineCoverage   float64 `json:"line_coverage,omitempty"`
+	BranchCoverage float64 `json:"branch_coverage,omitempty"`
+	Output         string  `json:"output,omitempty"`
+}
+
+// emitHeadlessEvent stamps the current time and writes ev as a single JSON
+// line to stdout. It's a no-op unless headlessMode is set, so the default
+// in-process (non-interactive, non-headless) run instead drives the
+// progress renderer.
+func emitHeadlessEvent(ev headlessEvent) {
+	if !headlessMode {
+		return
+	}
+	ev.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	fmt.Println(string(data))
+}
+
+// goTestJSONEvent mirrors the subset of `go test -json`'s TestEvent fields
+// we care about.
+type goTestJSONEvent struct {
+	Action string `json:"Action"`
+	Test   string `json:"Test"`
+	Output string `json:"Output"`
+}
+
+// runTestSequence runs a single response's test. By default it runs
+// `go test` in-process with no terminal window (headlessMode additionally
+// streams JSON events for CI consumption); -interactive restores the
+// terminal/screenshot path.
+func runTestSequence(responseFile, testFile, workDir, responseName, testLogFile string) (TestResult, error) {
+	if interactiveMode {
+		return openTerminalAndRunTest(responseFile, testFile, workDir, responseName, testLogFile)
+	}
+	return runHeadlessTest(responseFile, testFile, workDir, responseName, testLogFile)
+}
+
+// runMainTestSequence runs the main.go coverage analysis. By default it runs
+// in-process with no terminal window; -interactive restores the
+// terminal/screenshot path.
+func runMainTestSequence(taskDir, testType string) (string, error) {
+	if interactiveMode {
+		return openTerminalAndRunMainTest(taskDir, testType)
+	}
+	return runHeadlessMainTest(taskDir, testType)
+}
+
+// runHeadlessTest runs `go test -json` for a single response directly,
+// without a terminal or screenshot, streaming one headlessEvent per test
+// action to stdout.
+func runHeadlessTest(responseFile, testFile, workDir, responseName, testLogFile string) (TestResult, error) {
+	result := TestResult{Name: responseName}
+
+	tempResponse := filepath.Join(workDir, "temp_"+filepath.Base(responseFile))
+	if err := modifyPackageToMain(responseFile, tempResponse); err != nil {
+		result.Output = fmt.Sprintf("Failed to modify package: %v", err)
+		return result, err
+	}
+	defer os.Remove(tempResponse)
+
+	emitHeadlessEvent(headlessEvent{Action: "run", Name: responseName})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "go", "test", "-json", "-v",
+		filepath.Base(testFile), "temp_"+filepath.Base(responseFile), "testlog_shim.go")
+	cmd.Dir = workDir
+	setProcessGroup(cmd)
+	if testLogFile != "" {
+		cmd.Env = append(os.Environ(), testLogEnvVar+"="+testLogFile)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		result.Output = fmt.Sprintf("failed to open stdout pipe: %v", err)
+		return result, err
+	}
+	var stderrBuf strings.Builder
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderrBuf)
+
+	if err := cmd.Start(); err != nil {
+		result.Output = fmt.Sprintf("failed to start go test: %v", err)
+		return result, err
+	}
+	// Track cmd under activeLauncher even though we run it ourselves (rather
+	// than through Run) so killProcessesByTitlePrefix's KillGroup can still
+	// reach it; runHeadlessTest streams go test's JSON output line by line,
+	// which Run's all-at-once Result doesn't support.
+	activeLauncher.Track(responseName, cmd)
+	defer activeLauncher.Untrack(responseName, cmd)
+
+	var combinedOutput strings.Builder
+	success := true
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		var evt goTestJSONEvent
+		if err := json.Unmarshal([]byte(line), &evt); err != nil {
+			combinedOutput.WriteString(line + "\n")
+			continue
+		}
+		combinedOutput.WriteString(evt.Output)
+
+		switch evt.Action {
+		case "run", "pass", "fail", "output":
+			emitHeadlessEvent(headlessEvent{Action: evt.Action, Name: responseName, Output: evt.Output})
+		}
+		if evt.Action == "fail" {
+			success = false
+		}
+	}
+
+	waitErr := cmd.Wait()
+
+	result.Stdout = combinedOutput.String()
+	result.Stderr = stderrBuf.String()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		killProcessTree(cmd)
+		emitHeadlessEvent(headlessEvent{Action: "timeout", Name: responseName})
+		result.Output = combinedOutput.String() + "\n⏰ TEST TIMED OUT AFTER 10 SECONDS\n"
+		result.Success = false
+		result.TimedOut = true
+		return result, fmt.Errorf("test execution timed out")
+	}
+
+	if waitErr != nil {
+		success = false
+	}
+
+	result.Output = combinedOutput.String()
+	result.Success = success
+	return result, nil
+}
+
+// runHeadlessMainTest runs the main.go coverage analysis directly, without a
+// terminal or screenshot, emitting a "coverage" event with the parsed
+// coverage numbers once the command finishes.
+func runHeadlessMainTest(taskDir, testType string) (string, error) {
+	var cmd *exec.Cmd
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if testType == "line_coverage" {
+		cmd = exec.CommandContext(ctx, "go", "test", "-coverprofile=coverage.out")
+	} else {
+		cmd = exec.CommandContext(ctx, "gobco")
+	}
+	cmd.Dir = taskDir
+	setProcessGroup(cmd)
+
+	emitHeadlessEvent(headlessEvent{Action: "run", Name: testType})
+
+	var outputBuf strings.Builder
+	cmd.Stdout = &outputBuf
+	cmd.Stderr = &outputBuf
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to start %s: %w", testType, err)
+	}
+	// Track cmd under activeLauncher even though we run it ourselves (rather
+	// than through Run) so killProcessesByTitlePrefix's KillGroup can still
+	// reach it.
+	activeLauncher.Track(testType, cmd)
+	defer activeLauncher.Untrack(testType, cmd)
+
+	err := cmd.Wait()
+	output := []byte(outputBuf.String())
+	if ctx.Err() == context.DeadlineExceeded {
+		killProcessTree(cmd)
+		emitHeadlessEvent(headlessEvent{Action: "timeout", Name: testType})
+		return string(output) + "\n⏰ COVERAGE ANALYSIS TIMED OUT AFTER 10 SECONDS\n", fmt.Errorf("coverage analysis execution timed out")
+	}
+
+	ev := headlessEvent{Action: "coverage", Name: testType, Output: string(output)}
+	if testType == "line_coverage" {
+		ev.LineCoverage = parseCoverageReport(string(output))
+	} else {
+		ev.BranchCoverage, _, _ = parseGobcoCoverage(string(output))
+	}
+	emitHeadlessEvent(ev)
+
+	return string(output), err
+}
+
+// writeResultsJSON writes the aggregated results as a single JSON document
+// so downstream tooling can consume them without parsing result.txt/docx.
+func writeResultsJSON(path string, results []TestResult, mainCoverage MainCoverageResult) error {
+	doc := struct {
+		Results      []TestResult       `json:"results"`
+		MainCoverage MainCoverageResult `json:"main_coverage"`
+	}{
+		Results:      results,
+		MainCoverage: mainCoverage,
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling results: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,169 @@
+// Package launcher abstracts "run this command, visibly or not, and be able
+// to kill its whole process group later" behind a small interface, so the
+// runner no longer has to hard-code Windows PowerShell/.bat/taskkill
+// machinery at every call site that wants a terminal window. The headless
+// implementation is the cross-platform default (process groups via
+// syscall.SysProcAttr on Unix, CREATE_NEW_PROCESS_GROUP on Windows); the
+// terminal implementation additionally opens a visible window per OS
+// (PowerShell/cmd.exe, osascript+Terminal.app, xterm/gnome-terminal/konsole)
+// for screenshot capture.
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+// Result is what a Launcher.Run call produces: the command's combined
+// output (Stdout and Stderr interleaved, for display), the two streams
+// captured separately (for callers that need to report them apart, e.g. a
+// JUnit system-out/system-err split), and whether it had to be killed for
+// running past ctx's deadline.
+type Result struct {
+	Output   []byte
+	Stdout   []byte
+	Stderr   []byte
+	TimedOut bool
+}
+
+// Launcher runs a pre-configured *exec.Cmd (Dir/Env/Args already set by the
+// caller) and can later force-kill everything it's tracking under a given
+// title prefix.
+type Launcher interface {
+	// Run starts cmd and blocks until it exits or ctx is done. On timeout,
+	// Run kills cmd's whole process group and returns Result.TimedOut=true
+	// together with ctx's error, so callers can tell a timeout apart from an
+	// ordinary failure by checking TimedOut first.
+	Run(ctx context.Context, cmd *exec.Cmd, title string) (Result, error)
+	// KillGroup force-kills every process Run started under a title with
+	// this prefix. Safe to call even if nothing matches.
+	KillGroup(prefix string) error
+	// Track and Untrack register/deregister an already-started cmd under
+	// title so KillGroup can find it, for callers that need finer control
+	// than Run provides (e.g. streaming a command's output line by line)
+	// but still want their processes reachable through KillGroup.
+	Track(title string, cmd *exec.Cmd)
+	Untrack(title string, cmd *exec.Cmd)
+}
+
+// New returns the Launcher named by kind: "headless" (the default, no
+// visible window) or "terminal" (also opens a terminal window per OS, for
+// screenshot capture). An empty kind is treated as "headless".
+func New(kind string) (Launcher, error) {
+	switch kind {
+	case "", "headless":
+		return newHeadlessLauncher(), nil
+	case "terminal":
+		return newTerminalLauncher(), nil
+	default:
+		return nil, fmt.Errorf("launcher: unknown launcher %q (want \"headless\" or \"terminal\")", kind)
+	}
+}
+
+// syncWriter serializes writes to w. os/exec runs cmd.Stdout and cmd.Stderr
+// through separate copy goroutines, so a destination shared between both
+// (like combinedBuf below) needs its own lock: os/exec only de-dupes the
+// two streams into a single goroutine when Stdout and Stderr are the exact
+// same io.Writer value, and MultiWriter wrapping combinedBuf differently
+// for each stream means they never are.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// headlessLauncher runs cmd directly with no visible window, tracking
+// started commands by title so KillGroup can find them again.
+type headlessLauncher struct {
+	mu    sync.Mutex
+	procs map[string]*exec.Cmd
+}
+
+func newHeadlessLauncher() *headlessLauncher {
+	return &headlessLauncher{procs: map[string]*exec.Cmd{}}
+}
+
+func (h *headlessLauncher) Run(ctx context.Context, cmd *exec.Cmd, title string) (Result, error) {
+	setProcessGroup(cmd)
+
+	var combinedBuf, stdoutBuf, stderrBuf bytes.Buffer
+	combined := &syncWriter{w: &combinedBuf}
+	cmd.Stdout = io.MultiWriter(combined, &stdoutBuf)
+	cmd.Stderr = io.MultiWriter(combined, &stderrBuf)
+
+	h.Track(title, cmd)
+	defer h.Untrack(title, cmd)
+
+	done := make(chan struct{})
+	var runErr error
+	go func() {
+		defer close(done)
+		runErr = cmd.Run()
+	}()
+
+	result := func(timedOut bool) Result {
+		return Result{Output: combinedBuf.Bytes(), Stdout: stdoutBuf.Bytes(), Stderr: stderrBuf.Bytes(), TimedOut: timedOut}
+	}
+
+	select {
+	case <-done:
+		return result(false), runErr
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		// Wait for the run goroutine above to actually return before
+		// reading the buffers it writes to — killProcessGroup only signals
+		// the process group, it doesn't itself wait for cmd.Run() to see
+		// the exit and stop writing.
+		<-done
+		return result(true), ctx.Err()
+	}
+}
+
+func (h *headlessLauncher) KillGroup(prefix string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for title, cmd := range h.procs {
+		if len(title) >= len(prefix) && title[:len(prefix)] == prefix {
+			killProcessGroup(cmd)
+		}
+	}
+	return nil
+}
+
+// Track registers cmd under title so KillGroup can find it. Exported so
+// callers that run a command themselves (rather than through Run) can still
+// make it reachable through KillGroup. The title is stored with the same
+// "BCB_" prefix openWindow gives visible terminal windows (terminal.go), so
+// a single KillGroup("BCB") prefix matches processes tracked here and
+// windows opened there alike.
+func (h *headlessLauncher) Track(title string, cmd *exec.Cmd) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.procs[trackedTitle(title)] = cmd
+}
+
+// Untrack reverses Track. Safe to call even if cmd was never tracked, or was
+// already untracked.
+func (h *headlessLauncher) Untrack(title string, cmd *exec.Cmd) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := trackedTitle(title)
+	if h.procs[key] == cmd {
+		delete(h.procs, key)
+	}
+}
+
+// trackedTitle is the key Track/Untrack/KillGroup use for a given title.
+func trackedTitle(title string) string {
+	return "BCB_" + title
+}
@@ -0,0 +1,116 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// terminalLauncher opens a visible terminal window per OS (for screenshot
+// capture) in addition to actually running the command the same way
+// headlessLauncher does; the window is decorative; Run's Result still comes
+// from the real, separately-run process.
+type terminalLauncher struct {
+	*headlessLauncher
+}
+
+func newTerminalLauncher() *terminalLauncher {
+	return &terminalLauncher{headlessLauncher: newHeadlessLauncher()}
+}
+
+func (t *terminalLauncher) Run(ctx context.Context, cmd *exec.Cmd, title string) (Result, error) {
+	if err := openWindow(cmd, title); err != nil {
+		fmt.Printf("Warning: could not open terminal window for %s: %v\n", title, err)
+	}
+	return t.headlessLauncher.Run(ctx, cmd, title)
+}
+
+func (t *terminalLauncher) KillGroup(prefix string) error {
+	if err := t.headlessLauncher.KillGroup(prefix); err != nil {
+		return err
+	}
+	return killWindowsByTitlePrefix(prefix)
+}
+
+// shellCommandLine renders cmd as a single shell-quoted command line, for
+// embedding in the wrapper scripts below.
+func shellCommandLine(cmd *exec.Cmd) string {
+	parts := append([]string{cmd.Path}, cmd.Args[1:]...)
+	for i, p := range parts {
+		if strings.ContainsAny(p, " \t'\"") {
+			parts[i] = "'" + strings.ReplaceAll(p, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// openWindow opens a terminal window titled title, running cmd. It's
+// fire-and-forget: the window is for screenshots, not correctness, so a
+// failure to find a terminal emulator is reported but not fatal to Run.
+func openWindow(cmd *exec.Cmd, title string) error {
+	shellCmd := shellCommandLine(cmd)
+
+	switch runtime.GOOS {
+	case "windows":
+		script := fmt.Sprintf(`title BCB_%s
+cd /d "%s"
+%s
+`, title, cmd.Dir, shellCmd)
+		return exec.Command("cmd", "/c", "start", "cmd", "/k", script).Start()
+
+	case "darwin":
+		script := fmt.Sprintf(`tell application "Terminal"
+	activate
+	do script "cd '%s' && %s"
+end tell`, cmd.Dir, shellCmd)
+		return exec.Command("osascript", "-e", script).Start()
+
+	case "linux":
+		wrapped := fmt.Sprintf("cd '%s' && %s; exec bash", cmd.Dir, shellCmd)
+		terminals := [][]string{
+			{"gnome-terminal", "--", "bash", "-c", wrapped},
+			{"xterm", "-e", fmt.Sprintf("bash -c %q", wrapped)},
+			{"konsole", "-e", fmt.Sprintf("bash -c %q", wrapped)},
+		}
+		for _, terminal := range terminals {
+			if _, err := exec.LookPath(terminal[0]); err == nil {
+				return exec.Command(terminal[0], terminal[1:]...).Start()
+			}
+		}
+		return fmt.Errorf("no suitable terminal emulator found")
+
+	default:
+		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	}
+}
+
+// killWindowsByTitlePrefix closes any terminal windows opened by openWindow
+// whose title starts with prefix. Windows matches processes by window
+// title; macOS asks Terminal.app to close every window whose name contains
+// prefix. On Linux and other systems it does nothing, so windows opened
+// there stay open.
+func killWindowsByTitlePrefix(prefix string) error {
+	switch runtime.GOOS {
+	case "windows":
+		psCmd := fmt.Sprintf(
+			"Get-Process cmd, powershell, pwsh, WindowsTerminal -ErrorAction SilentlyContinue | "+
+				"Where-Object {$_.MainWindowTitle -like '%s*'} | "+
+				"Stop-Process -Force",
+			prefix,
+		)
+		out, err := exec.Command("powershell", "-NoProfile", "-Command", psCmd).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("launcher: PowerShell error: %v\n%s", err, out)
+		}
+		return nil
+
+	case "darwin":
+		exec.Command("osascript", "-e", `tell application "Terminal" to close (every window whose name contains "`+prefix+`")`).Run()
+		return nil
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package launcher
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup puts cmd in its own process group so killProcessGroup can
+// reap the whole subtree it spawns (e.g. `go test` forking helper
+// processes), not just the immediate child.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup sends SIGTERM to cmd's process group, waits briefly, and
+// escalates to SIGKILL if anything is still alive.
+//
+// It polls for exit with syscall.Kill(pgid, 0) rather than cmd.Wait(): the
+// caller (headlessLauncher.Run) already has its own goroutine blocked in
+// cmd.Run(), which calls Wait() internally, so a second Wait here would
+// race with it and — since a *exec.Cmd can only be waited on once — return
+// "exec: Wait was already called" almost instantly instead of blocking,
+// skipping the SIGKILL escalation below entirely.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	pgid := cmd.Process.Pid
+
+	syscall.Kill(-pgid, syscall.SIGTERM)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if err := syscall.Kill(-pgid, syscall.Signal(0)); err != nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	syscall.Kill(-pgid, syscall.SIGKILL)
+}
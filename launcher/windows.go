@@ -0,0 +1,25 @@
+//go:build windows
+
+package launcher
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in a new process group
+// (CREATE_NEW_PROCESS_GROUP). Killing still goes through taskkill /T in
+// killProcessGroup below rather than through that group.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// killProcessGroup force-kills cmd's whole process tree via taskkill, since
+// there's no portable signal-based equivalent on Windows.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	exec.Command("taskkill", "/F", "/T", "/PID", fmt.Sprint(cmd.Process.Pid)).Run()
+}
@@ -7,24 +7,31 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"baliance.com/gooxml/document"
+	"bigcodebench-auto/launcher"
 )
 
-// sendNotification sends a cross-platform notification
+// sendNotification sends a cross-platform notification. It's a no-op in
+// headless mode, where there's no desktop to notify.
 func sendNotification(title, message string) {
+	if headlessMode {
+		return
+	}
+
 	system := runtime.GOOS
 
 	switch system {
@@ -63,8 +70,15 @@ func sendNotification(title, message string) {
 	}
 }
 
+// screenshotMu staggers takeScreenshot calls so that concurrent workers
+// never race for control of the display.
+var screenshotMu sync.Mutex
+
 // takeScreenshot takes a screenshot and saves it to pictures folder only
 func takeScreenshot(screenshotName, taskDir string) error {
+	screenshotMu.Lock()
+	defer screenshotMu.Unlock()
+
 	// Create pictures folder if it doesn't exist
 	picturesFolder := filepath.Join(taskDir, "pictures")
 	if err := os.MkdirAll(picturesFolder, 0755); err != nil {
@@ -136,32 +150,13 @@ func takeScreenshot(screenshotName, taskDir string) error {
 	return cmd.Run()
 }
 
-// killProcessTree kills a process and its children
-func killProcessTree(cmd *exec.Cmd) {
-	if cmd.Process == nil {
-		return
-	}
-
-	system := runtime.GOOS
-	switch system {
-	case "windows":
-		// Kill process tree on Windows
-		killCmd := exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(cmd.Process.Pid))
-		killCmd.Run()
-	case "darwin", "linux":
-		// Kill process group on Unix-like systems
-		if cmd.Process != nil {
-			//syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
-		}
-	}
-}
+// killProcessTree kills a process and its children; see unix.go/windows.go
+// for the platform-specific implementation (build-tagged, since each side
+// only defines its own killProcessGroup{Unix,Windows}).
 
 // openTerminalAndRunTest opens a terminal and runs the test with timeout
-func openTerminalAndRunTest(responseFile, testFile, workDir, responseName string) (TestResult, error) {
-	system := runtime.GOOS
-
+func openTerminalAndRunTest(responseFile, testFile, workDir, responseName, testLogFile string) (TestResult, error) {
 	tempResponse := filepath.Join(workDir, "temp_"+filepath.Base(responseFile))
-	signalFile := filepath.Join(workDir, fmt.Sprintf("screenshot_done_%s.signal", responseName))
 
 	result := TestResult{
 		Name:           responseName,
@@ -173,15 +168,8 @@ func openTerminalAndRunTest(responseFile, testFile, workDir, responseName string
 		Cached:         false,
 	}
 
-	// Clean up any leftover signal files at the start
-	if _, err := os.Stat(signalFile); err == nil {
-		os.Remove(signalFile)
-		fmt.Printf("🧹 Cleaned up leftover signal file: %s\n", signalFile)
-	}
-
 	// Copy and modify the response file
-	err := modifyPackageToMain(responseFile, tempResponse)
-	if err != nil {
+	if err := modifyPackageToMain(responseFile, tempResponse); err != nil {
 		result.Output = fmt.Sprintf("Failed to modify package: %v", err)
 		return result, err
 	}
@@ -191,154 +179,31 @@ func openTerminalAndRunTest(responseFile, testFile, workDir, responseName string
 		if _, err := os.Stat(tempResponse); err == nil {
 			os.Remove(tempResponse)
 		}
-		// Clean up coverage files
 		coverageFiles := []string{"coverage.out", "coverage.html"}
 		for _, file := range coverageFiles {
 			if _, err := os.Stat(filepath.Join(workDir, file)); err == nil {
 				os.Remove(filepath.Join(workDir, file))
 			}
 		}
-		// Clean up signal file
-		if _, err := os.Stat(signalFile); err == nil {
-			os.Remove(signalFile)
-		}
 	}()
 
-	// Prepare the test command
-	testCmd := fmt.Sprintf("go test -v %s %s",
-		filepath.Base(testFile),
-		"temp_"+filepath.Base(responseFile))
-
-	var cmd *exec.Cmd
-
-	switch system {
-	case "windows":
-		// Create a batch file that waits for screenshot signal
-		batchContent := fmt.Sprintf(`@echo off
-title BCB_%s
-cd /d "%s"
-echo Testing %s...
-echo.
-%s
-echo.
-echo Test completed. Waiting for screenshot...
-:wait
-if exist "screenshot_done_%s.signal" (
-    del "screenshot_done_%s.signal" 2>nul
-    timeout /t 4 /nobreak > nul
-    echo Screenshot processing complete. Closing window...
-    timeout /t 1 /nobreak > nul
-    exit
-) else (
-    timeout /t 1 /nobreak > nul
-    goto wait
-)
-`, responseName, workDir, responseName, testCmd, responseName, responseName)
-
-		batchFile := filepath.Join(workDir, fmt.Sprintf("test_%s.bat", responseName))
-		if err := os.WriteFile(batchFile, []byte(batchContent), 0644); err != nil {
-			return result, err
-		}
-		defer os.Remove(batchFile)
-
-		cmd = exec.Command("cmd", "/c", "start", "cmd", "/c", batchFile)
-
-	case "darwin": // macOS
-		// Create an AppleScript that waits for signal file
-		script := fmt.Sprintf(`
-tell application "Terminal"
-	activate
-	set newTab to do script "cd '%s' && echo 'Testing %s...' && echo '' && %s && echo '' && echo 'Test completed. Waiting for screenshot...' && while [ ! -f 'screenshot_done_%s.signal' ]; do sleep 1; done && echo 'Screenshot taken. Closing window...' && rm -f 'screenshot_done_%s.signal' && sleep 1 && exit"
-end tell
-`, workDir, responseName, testCmd, responseName, responseName)
-
-		cmd = exec.Command("osascript", "-e", script)
-
-	case "linux":
-		// Create a script that waits for signal file
-		waitScript := fmt.Sprintf("cd '%s' && echo 'Testing %s...' && echo '' && %s && echo '' && echo 'Test completed. Waiting for screenshot...' && while [ ! -f 'screenshot_done_%s.signal' ]; do sleep 1; done && echo 'Screenshot taken. Closing window...' && rm -f 'screenshot_done_%s.signal' && sleep 1 && exit", workDir, responseName, testCmd, responseName, responseName)
-
-		terminals := [][]string{
-			{"gnome-terminal", "--", "bash", "-c", waitScript},
-			{"xterm", "-e", fmt.Sprintf("bash -c \"%s\"", waitScript)},
-			{"konsole", "-e", fmt.Sprintf("bash -c \"%s\"", waitScript)},
-		}
-
-		var terminalErr error
-		for _, terminal := range terminals {
-			cmd = exec.Command(terminal[0], terminal[1:]...)
-			if _, terminalErr = exec.LookPath(terminal[0]); terminalErr == nil {
-				break
-			}
-		}
-		if terminalErr != nil {
-			return result, fmt.Errorf("no suitable terminal emulator found")
-		}
-
-	default:
-		return result, fmt.Errorf("unsupported operating system: %s", system)
-	}
-
-	// Start the terminal
-	if err := cmd.Start(); err != nil {
-		return result, fmt.Errorf("failed to start terminal: %w", err)
-	}
-
-	// Run test separately with timeout
 	testCmdExec := exec.Command("go", "test", "-v",
 		filepath.Base(testFile),
 		"temp_"+filepath.Base(responseFile))
 	testCmdExec.Dir = workDir
-
-	// Set up process group for proper cleanup on Unix systems
-	if system != "windows" {
-		//testCmdExec.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	testCmdExec.Args = append(testCmdExec.Args, "testlog_shim.go")
+	if testLogFile != "" {
+		testCmdExec.Env = append(os.Environ(), testLogEnvVar+"="+testLogFile)
 	}
 
-	// Create a context with 10-second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	startTime := time.Now()
-	var output []byte
-	var testErr error
-	timedOut := false
-
-	// Run the test command with timeout
-	done := make(chan struct{})
-	go func() {
-		defer close(done)
-		output, testErr = testCmdExec.CombinedOutput()
-	}()
-
-	select {
-	case <-done:
-		// Test completed within timeout
-		testDuration := time.Since(startTime)
-		fmt.Printf("⏱️  Test completed in %.2f seconds\n", testDuration.Seconds())
-
-		// Wait for test to display (test time + small buffer for display)
-		displayWait := testDuration + (1 * time.Second)
-		time.Sleep(displayWait)
-
-	case <-ctx.Done():
-		// Test timed out
-		timedOut = true
-		fmt.Printf("⏰ Test timed out after 10 seconds, killing process...\n")
+	launchResult, runErr := activeLauncher.Run(ctx, testCmdExec, responseName)
+	fmt.Printf("⏱️  Test finished in %.2f seconds\n", time.Since(startTime).Seconds())
 
-		// Kill the test process
-		if testCmdExec.Process != nil {
-			killProcessTree(testCmdExec)
-		}
-
-		// Wait a bit for the terminal to show the timeout
-		time.Sleep(2 * time.Second)
-
-		output = []byte("Test timed out after 10 seconds")
-		testErr = fmt.Errorf("test execution timed out")
-	}
-
-	// Take screenshot while terminal is still open
+	// Take screenshot once the test (and terminal window, if any) is done.
 	fmt.Printf("📸 Taking screenshot for %s...\n", responseName)
 	screenshotName := fmt.Sprintf("%s.png", responseName)
 	if err := takeScreenshot(screenshotName, workDir); err != nil {
@@ -347,23 +212,17 @@ end tell
 		fmt.Printf("✅ Screenshot saved for %s\n", responseName)
 	}
 
-	// Signal terminal to close by creating the signal file
-	if err := os.WriteFile(signalFile, []byte("done"), 0644); err != nil {
-		fmt.Printf("Warning: Could not create signal file for %s: %v\n", responseName, err)
-	}
-
-	// Wait a moment for terminal to process the signal and close gracefully
-	time.Sleep(2 * time.Second)
-
-	// Prepare result output
-	result.Output = fmt.Sprintf("=== Test Output ===\n%s\n", string(output))
-	if timedOut {
+	result.Output = fmt.Sprintf("=== Test Output ===\n%s\n", string(launchResult.Output))
+	result.Stdout = string(launchResult.Stdout)
+	result.Stderr = string(launchResult.Stderr)
+	result.TimedOut = launchResult.TimedOut
+	if launchResult.TimedOut {
 		result.Output += "\n⏰ TEST TIMED OUT AFTER 10 SECONDS\n"
 		result.Success = false
-	} else if testErr == nil {
+	} else if runErr == nil {
 		result.Success = true
 	} else {
-		result.Output += fmt.Sprintf("\nTest Error: %v\n", testErr)
+		result.Output += fmt.Sprintf("\nTest Error: %v\n", runErr)
 	}
 
 	return result, nil
@@ -371,97 +230,6 @@ end tell
 
 // openTerminalAndRunMainTest opens a terminal and runs coverage analysis for main.go with timeout
 func openTerminalAndRunMainTest(taskDir, testType string) (string, error) {
-	system := runtime.GOOS
-	signalFile := filepath.Join(taskDir, fmt.Sprintf("screenshot_done_%s.signal", testType))
-
-	// Clean up any leftover signal files at the start
-	if _, err := os.Stat(signalFile); err == nil {
-		os.Remove(signalFile)
-		fmt.Printf("🧹 Cleaned up leftover signal file: %s\n", signalFile)
-	}
-
-	var testCmd string
-	if testType == "line_coverage" {
-		testCmd = "go test -coverprofile=coverage.out && go tool cover -html=coverage.out -o coverage.html"
-	} else { // branch_coverage
-		testCmd = "gobco"
-	}
-
-	var cmd *exec.Cmd
-
-	switch system {
-	case "windows":
-		// Create a batch file that waits for screenshot signal
-		batchContent := fmt.Sprintf(`@echo off
-cd /d "%s"
-echo Running %s analysis for main.go...
-echo.
-%s
-echo.
-echo Analysis completed. Waiting for screenshot...
-:wait
-if exist "screenshot_done_%s.signal" (
-    del "screenshot_done_%s.signal" 2>nul
-    timeout /t 4 /nobreak > nul
-    echo Screenshot processing complete. Closing window...
-    timeout /t 1 /nobreak > nul
-    exit
-) else (
-    timeout /t 1 /nobreak > nul
-    goto wait
-)
-`, taskDir, testType, testCmd, testType, testType)
-
-		batchFile := filepath.Join(taskDir, fmt.Sprintf("main_%s.bat", testType))
-		if err := os.WriteFile(batchFile, []byte(batchContent), 0644); err != nil {
-			return "", err
-		}
-		defer os.Remove(batchFile)
-
-		cmd = exec.Command("cmd", "/c", "start", "cmd", "/c", batchFile)
-
-	case "darwin": // macOS
-		// Create an AppleScript that waits for signal file
-		script := fmt.Sprintf(`
-tell application "Terminal"
-	activate
-	set newTab to do script "cd '%s' && echo 'Running %s analysis for main.go...' && echo '' && %s && echo '' && echo 'Analysis completed. Waiting for screenshot...' && while [ ! -f 'screenshot_done_%s.signal' ]; do sleep 1; done && echo 'Screenshot taken. Closing window...' && rm -f 'screenshot_done_%s.signal' && sleep 1 && exit"
-end tell
-`, taskDir, testType, testCmd, testType, testType)
-
-		cmd = exec.Command("osascript", "-e", script)
-
-	case "linux":
-		// Create a script that waits for signal file
-		waitScript := fmt.Sprintf("cd '%s' && echo 'Running %s analysis for main.go...' && echo '' && %s && echo '' && echo 'Analysis completed. Waiting for screenshot...' && while [ ! -f 'screenshot_done_%s.signal' ]; do sleep 1; done && echo 'Screenshot taken. Closing window...' && rm -f 'screenshot_done_%s.signal' && sleep 1 && exit", taskDir, testType, testCmd, testType, testType)
-
-		terminals := [][]string{
-			{"gnome-terminal", "--", "bash", "-c", waitScript},
-			{"xterm", "-e", fmt.Sprintf("bash -c \"%s\"", waitScript)},
-			{"konsole", "-e", fmt.Sprintf("bash -c \"%s\"", waitScript)},
-		}
-
-		var terminalErr error
-		for _, terminal := range terminals {
-			cmd = exec.Command(terminal[0], terminal[1:]...)
-			if _, terminalErr = exec.LookPath(terminal[0]); terminalErr == nil {
-				break
-			}
-		}
-		if terminalErr != nil {
-			return "", fmt.Errorf("no suitable terminal emulator found")
-		}
-
-	default:
-		return "", fmt.Errorf("unsupported operating system: %s", system)
-	}
-
-	// Start the terminal
-	if err := cmd.Start(); err != nil {
-		return "", fmt.Errorf("failed to start terminal: %w", err)
-	}
-
-	// Run the actual command with timeout
 	var actualCmd *exec.Cmd
 	if testType == "line_coverage" {
 		actualCmd = exec.Command("go", "test", "-coverprofile=coverage.out")
@@ -470,55 +238,13 @@ end tell
 	}
 	actualCmd.Dir = taskDir
 
-	// Set up process group for proper cleanup on Unix systems
-	if system != "windows" {
-		//actualCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	}
-
-	// Create a context with 10-second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	startTime := time.Now()
-	var output []byte
-	var err error
-	timedOut := false
-
-	// Run the command with timeout
-	done := make(chan struct{})
-	go func() {
-		defer close(done)
-		output, err = actualCmd.CombinedOutput()
-	}()
-
-	select {
-	case <-done:
-		// Command completed within timeout
-		testDuration := time.Since(startTime)
-		fmt.Printf("⏱️  Coverage analysis completed in %.2f seconds\n", testDuration.Seconds())
+	launchResult, err := activeLauncher.Run(ctx, actualCmd, testType)
+	fmt.Printf("⏱️  Coverage analysis finished in %.2f seconds\n", time.Since(startTime).Seconds())
 
-		// Wait for command to complete and display
-		displayWait := testDuration + (1 * time.Second)
-		time.Sleep(displayWait)
-
-	case <-ctx.Done():
-		// Command timed out
-		timedOut = true
-		fmt.Printf("⏰ Coverage analysis timed out after 10 seconds, killing process...\n")
-
-		// Kill the process
-		if actualCmd.Process != nil {
-			killProcessTree(actualCmd)
-		}
-
-		// Wait a bit for the terminal to show the timeout
-		time.Sleep(2 * time.Second)
-
-		output = []byte("Coverage analysis timed out after 10 seconds")
-		err = fmt.Errorf("coverage analysis execution timed out")
-	}
-
-	// Take screenshot while terminal is still open
 	var screenshotName string
 	if testType == "line_coverage" {
 		screenshotName = "ideal_line_coverage.png"
@@ -533,16 +259,8 @@ end tell
 		fmt.Printf("✅ Screenshot saved for %s\n", testType)
 	}
 
-	// Signal terminal to close by creating the signal file
-	if err := os.WriteFile(signalFile, []byte("done"), 0644); err != nil {
-		fmt.Printf("Warning: Could not create signal file for %s: %v\n", testType, err)
-	}
-
-	// Wait a moment for terminal to process the signal and close gracefully
-	time.Sleep(2 * time.Second)
-
-	result := string(output)
-	if timedOut {
+	result := string(launchResult.Output)
+	if launchResult.TimedOut {
 		result += "\n⏰ COVERAGE ANALYSIS TIMED OUT AFTER 10 SECONDS\n"
 	}
 
@@ -589,59 +307,6 @@ func calculateFileHash(filePath string) (string, error) {
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
-// generateCombinedHash generates hash from response file and test file
-func generateCombinedHash(responseFile, testFile string) (string, error) {
-	responseHash, err := calculateFileHash(responseFile)
-	if err != nil {
-		return "", fmt.Errorf("error hashing response file: %w", err)
-	}
-
-	testHash, err := calculateFileHash(testFile)
-	if err != nil {
-		return "", fmt.Errorf("error hashing test file: %w", err)
-	}
-
-	combinedString := responseHash + testHash
-	hasher := sha256.New()
-	hasher.Write([]byte(combinedString))
-	return hex.EncodeToString(hasher.Sum(nil)), nil
-}
-
-// generateMainGoHash generates hash for main.go and main_test.go
-func generateMainGoHash(taskDir string) (string, error) {
-	mainGoFile := filepath.Join(taskDir, "main.go")
-	testFile := filepath.Join(taskDir, "main_test.go")
-
-	mainHash, err := calculateFileHash(mainGoFile)
-	if err != nil {
-		return "", fmt.Errorf("error hashing main.go: %w", err)
-	}
-
-	testHash, err := calculateFileHash(testFile)
-	if err != nil {
-		return "", fmt.Errorf("error hashing main_test.go: %w", err)
-	}
-
-	combinedString := mainHash + testHash
-	hasher := sha256.New()
-	hasher.Write([]byte(combinedString))
-	return hex.EncodeToString(hasher.Sum(nil)), nil
-}
-
-// readHashCache reads the hash from cache file
-func readHashCache(cacheFile string) (string, error) {
-	data, err := os.ReadFile(cacheFile)
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(data)), nil
-}
-
-// writeHashCache writes the hash to cache file
-func writeHashCache(cacheFile, hash string) error {
-	return os.WriteFile(cacheFile, []byte(hash), 0644)
-}
-
 // getPackageName extracts package name from a Go file
 func getPackageName(goFilePath string) (string, error) {
 	content, err := os.ReadFile(goFilePath)
@@ -682,6 +347,10 @@ type TestResult struct {
 	Name           string
 	Success        bool
 	Output         string
+	Stdout         string
+	Stderr         string
+	DurationMs     int64
+	TimedOut       bool
 	LineCoverage   float64
 	BranchCoverage float64
 	CoverageReport string
@@ -693,15 +362,26 @@ type MainCoverageResult struct {
 	LineCoverage   float64
 	BranchCoverage float64
 	CoverageReport string
+	Conditions     []Condition
 	Cached         bool
 }
 
+// Condition is one gobco-reported branch condition, parsed out of the raw
+// coverage text by parseGobcoCoverage so the report package can emit it as
+// structured JSON instead of a free-form line.
+type Condition struct {
+	File       string
+	Line       int
+	Expr       string
+	TrueCount  int
+	FalseCount int
+}
+
 // runMainCoverageAnalysis runs coverage analysis on main.go if it exists
 func runMainCoverageAnalysis(taskDir string) MainCoverageResult {
 	mainGoFile := filepath.Join(taskDir, "main.go")
 	testFile := filepath.Join(taskDir, "main_test.go")
-	cacheFile := filepath.Join(taskDir, "main_coverage.cache")
-	resultFile := filepath.Join(taskDir, "main_coverage_result.txt")
+	inputsFile := filepath.Join(taskDir, "main_coverage.inputs")
 
 	result := MainCoverageResult{
 		LineCoverage:   0.0,
@@ -721,27 +401,32 @@ func runMainCoverageAnalysis(taskDir string) MainCoverageResult {
 		return result
 	}
 
-	// Generate current hash
-	currentHash, err := generateMainGoHash(taskDir)
+	// Build the input log (env vars + every file the coverage run depends
+	// on, transitively) and derive a cache key from it so a cached result is
+	// only reused when every one of those inputs still matches.
+	inputLog, err := buildMainCoverageInputLog(taskDir)
 	if err != nil {
-		result.CoverageReport = fmt.Sprintf("Failed to generate hash: %v", err)
+		result.CoverageReport = fmt.Sprintf("Failed to build cache input log: %v", err)
 		return result
 	}
+	cacheKey := mainCoverageCacheKey(inputLog)
+	cacheFile := filepath.Join(taskDir, fmt.Sprintf("main_coverage_%s.cache", cacheKey))
+	resultFile := filepath.Join(taskDir, fmt.Sprintf("main_coverage_%s_result.txt", cacheKey))
 
-	// Check if hash matches cached version
-	if cachedHash, err := readHashCache(cacheFile); err == nil && cachedHash == currentHash {
-		// Hash matches, try to load cached result
+	// Check if the content-addressed cache for this exact set of inputs exists
+	if _, err := os.Stat(cacheFile); err == nil {
 		if data, err := os.ReadFile(resultFile); err == nil {
 			result.CoverageReport = string(data)
 			result.Cached = true
 
 			// Parse cached coverage values
 			result.LineCoverage = parseCoverageReport(result.CoverageReport)
-			if branchCov, _ := parseGobcoCoverage(result.CoverageReport); branchCov > 0 {
+			if branchCov, _, conditions := parseGobcoCoverage(result.CoverageReport); branchCov > 0 {
 				result.BranchCoverage = branchCov
+				result.Conditions = conditions
 			}
 
-			fmt.Printf("🚀 main.go coverage - Using cached result (hash match)\n")
+			fmt.Printf("🚀 main.go coverage - Using cached result (inputs unchanged)\n")
 			return result
 		}
 	}
@@ -761,9 +446,11 @@ func runMainCoverageAnalysis(taskDir string) MainCoverageResult {
 
 	coverageOutput.WriteString("=== Coverage Analysis for main.go ===\n\n")
 
+	var conditions []Condition
+
 	// Run line coverage analysis with terminal and screenshot
 	fmt.Printf("📊 Running line coverage analysis for main.go...\n")
-	if lineOutput, err := openTerminalAndRunMainTest(taskDir, "line_coverage"); err == nil {
+	if lineOutput, err := runMainTestSequence(taskDir, "line_coverage"); err == nil {
 		coverageOutput.WriteString("=== Line Coverage Analysis ===\n")
 		coverageOutput.WriteString(lineOutput)
 		lineCoverage = parseCoverageReport(lineOutput)
@@ -773,12 +460,13 @@ func runMainCoverageAnalysis(taskDir string) MainCoverageResult {
 
 	// Run branch coverage analysis with terminal and screenshot
 	fmt.Printf("📊 Running branch coverage analysis for main.go...\n")
-	if branchOutput, err := openTerminalAndRunMainTest(taskDir, "branch_coverage"); err == nil {
+	if branchOutput, err := runMainTestSequence(taskDir, "branch_coverage"); err == nil {
 		coverageOutput.WriteString("\n=== Branch Coverage Analysis (gobco) ===\n")
 		coverageOutput.WriteString(branchOutput)
 
-		branchCov, coverageReport := parseGobcoCoverage(branchOutput)
+		branchCov, coverageReport, parsedConditions := parseGobcoCoverage(branchOutput)
 		branchCoverage = branchCov
+		conditions = parsedConditions
 		if coverageReport != "" {
 			coverageOutput.WriteString("\n")
 			coverageOutput.WriteString(coverageReport)
@@ -791,14 +479,20 @@ func runMainCoverageAnalysis(taskDir string) MainCoverageResult {
 	result.LineCoverage = lineCoverage
 	result.BranchCoverage = branchCoverage
 	result.CoverageReport = coverageOutput.String()
+	result.Conditions = conditions
 
-	// Cache the result
+	// Cache the result, keyed by cacheKey so other env/dep combinations
+	// already on disk are left untouched.
 	if err := os.WriteFile(resultFile, []byte(result.CoverageReport), 0644); err != nil {
 		fmt.Printf("Warning: Could not write main coverage result file: %v\n", err)
 	}
 
-	if err := writeHashCache(cacheFile, currentHash); err != nil {
-		fmt.Printf("Warning: Could not write main coverage hash cache: %v\n", err)
+	if err := os.WriteFile(cacheFile, []byte(cacheKey), 0644); err != nil {
+		fmt.Printf("Warning: Could not write main coverage cache marker: %v\n", err)
+	}
+
+	if err := os.WriteFile(inputsFile, []byte(strings.Join(inputLog, "\n")+"\n"), 0644); err != nil {
+		fmt.Printf("Warning: Could not write main coverage inputs log: %v\n", err)
 	}
 
 	return result
@@ -816,11 +510,22 @@ func parseCoverageReport(output string) float64 {
 	return 0.0
 }
 
-// parseGobcoCoverage parses gobco output to extract branch coverage
-func parseGobcoCoverage(output string) (float64, string) {
+// conditionLineRE matches gobco's per-condition report lines, e.g.:
+//
+//	path/to/file.go:12:5: condition "x > 0" is true 3 times and false 0 times
+//
+// Lines that mention a condition but don't match this exact shape (e.g.
+// "condition ... was never false") still make it into the text coverage
+// report below, they just don't get a parsed Condition entry.
+var conditionLineRE = regexp.MustCompile(`^(\S+\.go):(\d+)(?::\d+)?:\s+condition\s+"(.+)"\s+is\s+true\s+(\d+)\s+times?\s+and\s+false\s+(\d+)\s+times?`)
+
+// parseGobcoCoverage parses gobco output to extract branch coverage, the
+// human-readable report text, and the individual conditions it mentions.
+func parseGobcoCoverage(output string) (float64, string, []Condition) {
 	lines := strings.Split(output, "\n")
 	var coverageReport strings.Builder
 	var branchCoverage float64
+	var conditions []Condition
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -842,10 +547,23 @@ func parseGobcoCoverage(output string) (float64, string) {
 		// Include condition details
 		if strings.Contains(line, "condition") && (strings.Contains(line, "never") || strings.Contains(line, "times")) {
 			coverageReport.WriteString(line + "\n")
+
+			if m := conditionLineRE.FindStringSubmatch(line); m != nil {
+				lineNum, _ := strconv.Atoi(m[2])
+				trueCount, _ := strconv.Atoi(m[4])
+				falseCount, _ := strconv.Atoi(m[5])
+				conditions = append(conditions, Condition{
+					File:       m[1],
+					Line:       lineNum,
+					Expr:       m[3],
+					TrueCount:  trueCount,
+					FalseCount: falseCount,
+				})
+			}
 		}
 	}
 
-	return branchCoverage, coverageReport.String()
+	return branchCoverage, coverageReport.String(), conditions
 }
 
 // loadCachedResult loads cached result from result.txt file
@@ -887,59 +605,6 @@ func renameResponse(oldName string) (string, error) {
 	return "response_" + newSuffix, nil
 }
 
-// runGoTest runs go test for a specific response file
-func runGoTest(responseFile, testFile, workDir, taskDir string) TestResult {
-	responseFolder := filepath.Dir(responseFile)
-	responseName := filepath.Base(responseFile)
-	responseName = strings.TrimSuffix(responseName, ".go")
-
-	cacheFile := filepath.Join(responseFolder, "hash.cache")
-
-	// Generate current hash
-	currentHash, err := generateCombinedHash(responseFile, testFile)
-	if err != nil {
-		return TestResult{
-			Name:    responseName,
-			Success: false,
-			Output:  fmt.Sprintf("Failed to generate hash: %v", err),
-		}
-	}
-
-	// Check if hash matches cached version
-	if cachedHash, err := readHashCache(cacheFile); err == nil && cachedHash == currentHash {
-		// Hash matches, try to load cached result
-		if cachedResult, err := loadCachedResult(responseFolder); err == nil {
-			fmt.Printf("🚀 %s - Using cached result (hash match)\n", responseName)
-			return cachedResult
-		}
-	}
-
-	renamedResponseName, err := renameResponse(responseName)
-	if err != nil {
-		fmt.Printf("Cannot rename reponse; using old name %s: %v\n", responseName, err)
-		renamedResponseName = responseName
-	}
-	// Open terminal and run test
-	result, err := openTerminalAndRunTest(responseFile, testFile, workDir, renamedResponseName)
-	if err != nil {
-		result.Output = fmt.Sprintf("Failed to run test in terminal: %v", err)
-		return result
-	}
-
-	// Write individual result.txt file
-	resultFile := filepath.Join(responseFolder, "result.txt")
-	if err := os.WriteFile(resultFile, []byte(result.Output), 0644); err != nil {
-		fmt.Printf("Warning: Could not write result file for %s: %v\n", responseName, err)
-	}
-
-	// Update hash cache
-	if err := writeHashCache(cacheFile, currentHash); err != nil {
-		fmt.Printf("Warning: Could not write hash cache for %s: %v\n", responseName, err)
-	}
-
-	return result
-}
-
 // writeResults writes all test results to a file
 func writeResults(resultsFile string, taskID string, results []TestResult, mainCoverage MainCoverageResult) error {
 	file, err := os.Create(resultsFile)
@@ -1014,21 +679,10 @@ func writeResults(resultsFile string, taskID string, results []TestResult, mainC
 }
 
 func killProcessesByTitlePrefix(prefix string) error {
-	// build a PowerShell one‑liner
-	psCmd := fmt.Sprintf(
-		"Get-Process cmd, powershell, pwsh, WindowsTerminal -ErrorAction SilentlyContinue | "+
-			"Where-Object {$_.MainWindowTitle -like '%s_response_[A-Z]'} | "+
-			"Stop-Process -Force",
-		prefix,
-	)
-
-	// run it and capture output
-	out, err := exec.Command("powershell", "-NoProfile", "-Command", psCmd).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("PowerShell error: %v\n%s", err, out)
+	if err := activeLauncher.KillGroup(prefix); err != nil {
+		return err
 	}
-
-	fmt.Printf("✅ Killed any windows matching %s_response_[A-Z]\n", prefix)
+	fmt.Printf("✅ Killed any windows/processes under %s\n", prefix)
 	return nil
 }
 
@@ -1168,19 +822,70 @@ func generateDocxFromImages(folder string) (string, error) {
 	return out, nil
 }
 
+// main just hands off to run's exit code; run is the separate entry point
+// testscript_test.go registers as the "bcb" self-exec command so
+// testdata/script/*.txtar can drive the real CLI end to end.
 func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	if len(os.Args) > 1 && os.Args[1] == "clean" {
+		taskID, err := readEnvFile("env")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		if err := cleanBcbDir(taskID); err != nil {
+			fmt.Printf("Error cleaning .bcb dir: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Removed %s\n", bcbDir(taskID))
+		return 0
+	}
+
+	parallel := flag.Int("parallel", runtime.NumCPU(), "number of responses to test concurrently (1 = sequential)")
+	headlessFlag := flag.Bool("headless", false, "stream structured JSON test events to stdout instead of the progress table, and skip notifications (also on if CI=true, or on Linux if DISPLAY is unset)")
+	force := flag.Bool("force", false, "bypass the .bcb dependency check and rebuild every response")
+	interactive := flag.Bool("interactive", false, "deprecated alias for -launcher=terminal")
+	launcherName := flag.String("launcher", "headless", "how to run each response's go test: \"headless\" (no window) or \"terminal\" (open a window and take a screenshot)")
+	verbose := flag.Bool("verbose", false, "print the reason a cached result was invalidated (or missing) for each response")
+	jsonPath := flag.String("json", "", "also write a structured per-response JSON report (see the report package) to this path")
+	junitPath := flag.String("junit", "", "also write a JUnit XML report to this path, for CI test-result dashboards")
+	flag.Parse()
+
+	forceRebuild = *force
+	verboseMode = *verbose
+
+	if *interactive {
+		*launcherName = "terminal"
+	}
+	interactiveMode = *launcherName == "terminal"
+
+	var err error
+	activeLauncher, err = launcher.New(*launcherName)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	headlessMode = isHeadless(*headlessFlag)
+	if headlessMode {
+		fmt.Println("Running in headless mode")
+	}
+
 	// Read environment file
 	taskID, err := readEnvFile("env")
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 
 	// Check if task directory exists
 	taskDir := taskID
 	if _, err := os.Stat(taskDir); os.IsNotExist(err) {
 		fmt.Printf("Error: Task directory '%s' not found!\n", taskID)
-		os.Exit(1)
+		return 1
 	}
 
 	fmt.Printf("Working with task ID: %s\n", taskID)
@@ -1193,12 +898,13 @@ func main() {
 	testFile := filepath.Join(taskDir, "main_test.go")
 	if _, err := os.Stat(testFile); os.IsNotExist(err) {
 		fmt.Printf("Error: main_test.go not found in %s\n", taskDir)
-		os.Exit(1)
+		return 1
 	}
 
-	// Find all response folders
+	// Find all response folders. 26 matches renameResponse's supported
+	// response1..response26 -> response_A..response_Z range.
 	var responsePairs [][]string
-	for i := 1; i <= 9; i++ {
+	for i := 1; i <= 26; i++ {
 		responseFolder := filepath.Join(taskDir, "response"+strconv.Itoa(i))
 		responseFile := filepath.Join(responseFolder, "response"+strconv.Itoa(i)+".go")
 
@@ -1211,19 +917,50 @@ func main() {
 
 	if len(responsePairs) == 0 {
 		fmt.Println("Error: No response folders with .go files found!")
-		os.Exit(1)
+		return 1
 	}
 
 	fmt.Printf("Found %d response files to test\n", len(responsePairs))
 
 	// Results tracking
-	var results []TestResult
 	passedCount := 0
 	cachedCount := 0
 	timedOutCount := 0
 
-	// Run coverage analysis on main.go if it exists (separate from individual response tests)
+	// Test each response, up to -parallel at a time
+	var responseFiles []string
+	for _, pair := range responsePairs {
+		responseFiles = append(responseFiles, pair[1])
+	}
+
+	fmt.Printf("\n%s\n", strings.Repeat("=", 50))
+	fmt.Printf("Testing %d responses (parallel=%d)...\n", len(responseFiles), *parallel)
+	fmt.Printf("%s\n", strings.Repeat("=", 50))
+
+	// Coverage analysis and response testing already do their own
+	// fine-grained, redo-style caching (coverage_cache.go, manifestcache.go
+	// via depcache.go) and need to run every invocation to populate
+	// mainCoverage/results in memory for this process, cache hit or not —
+	// so they run directly rather than as build.Scheduler targets, which
+	// would skip Build (and the in-memory assignment) entirely on a coarse
+	// cache hit.
 	mainCoverage := runMainCoverageAnalysis(taskDir)
+	results := RunAll(responseFiles, testFile, taskDir, *parallel)
+
+	// Result writing and docx generation, on the other hand, have no
+	// cache of their own: wire them as a build.Scheduler DAG so docx only
+	// regenerates when pictures/ actually changed, without rewriting
+	// result.txt/results.json on every run regardless of whether coverage
+	// or responses actually changed.
+	scheduler := newPipelineScheduler(taskDir, forceRebuild)
+	scheduler.Register(
+		&resultsTarget{taskDir: taskDir, taskID: taskID, testFile: testFile, responseFiles: responseFiles, coverage: &mainCoverage, results: &results},
+		&reportTarget{taskDir: taskDir, taskID: taskID, testFile: testFile, responseFiles: responseFiles, jsonPath: *jsonPath, junitPath: *junitPath, coverage: &mainCoverage, results: &results},
+		&docsTarget{taskDir: taskDir},
+	)
+	if err := scheduler.Run("docs", "report"); err != nil {
+		fmt.Printf("Error running pipeline: %v\n", err)
+	}
 
 	fmt.Printf("Main line coverage: %.1f%%\n", mainCoverage.LineCoverage)
 	fmt.Printf("Main branch coverage: %.1f%%\n", mainCoverage.BranchCoverage)
@@ -1231,48 +968,19 @@ func main() {
 		fmt.Printf("Main coverage analysis: CACHED\n")
 	}
 
-	// Test each response
-	for _, pair := range responsePairs {
-		responseFile := pair[1]
-		responseName := filepath.Base(responseFile)
-		responseName = strings.TrimSuffix(responseName, ".go")
-
-		fmt.Printf("\n%s\n", strings.Repeat("=", 50))
-		fmt.Printf("Testing %s...\n", responseName)
-		fmt.Printf("%s\n", strings.Repeat("=", 50))
-
-		result := runGoTest(responseFile, testFile, taskDir, taskDir)
-		results = append(results, result)
-
-		// Check for timeout
-		isTimedOut := strings.Contains(result.Output, "TIMED OUT")
-		if isTimedOut {
+	for _, result := range results {
+		if strings.Contains(result.Output, "TIMED OUT") {
 			timedOutCount++
 		}
-
 		if result.Success {
 			passedCount++
 			if result.Cached {
 				cachedCount++
-				fmt.Printf("✅ %s PASSED! (cached)\n", responseName)
-			} else {
-				fmt.Printf("✅ %s PASSED!\n", responseName)
-				sendNotification("Go Test Passed! 🎉", fmt.Sprintf("%s passed all tests!", responseName))
-			}
-		} else {
-			if isTimedOut {
-				fmt.Printf("⏰ %s TIMED OUT (failed)\n", responseName)
-			} else {
-				fmt.Printf("❌ %s FAILED\n", responseName)
 			}
 		}
 	}
 
-	// Write results to file
 	resultsFile := filepath.Join(taskDir, "result.txt")
-	if err := writeResults(resultsFile, taskID, results, mainCoverage); err != nil {
-		fmt.Printf("Error writing results: %v\n", err)
-	}
 
 	fmt.Printf("\n%s\n", strings.Repeat("=", 60))
 	fmt.Printf("FINAL RESULTS\n")
@@ -1299,6 +1007,12 @@ func main() {
 
 	absResultsPath, _ := filepath.Abs(resultsFile)
 	fmt.Printf("\nResults written to: %s\n", absResultsPath)
+	if *jsonPath != "" {
+		fmt.Printf("JSON report written to: %s\n", *jsonPath)
+	}
+	if *junitPath != "" {
+		fmt.Printf("JUnit report written to: %s\n", *junitPath)
+	}
 
 	if passedCount > 0 {
 		var message string
@@ -1327,9 +1041,8 @@ func main() {
 		fmt.Println(err)
 	}
 
-	fmt.Printf("\n🧹 Generating docs...\n")
-	_, err = generateDocxFromImages(path.Join(taskDir, "pictures"))
-	if err != nil {
-		fmt.Println(err)
+	if timedOutCount > 0 || passedCount < len(results) {
+		return 1
 	}
+	return 0
 }
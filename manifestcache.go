@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// testLogEnvVar is exported to the test subprocess so code using the
+// testlog helpers (see testlog/testlog.go) knows where to append the env
+// vars and files it actually reads.
+const testLogEnvVar = "BCB_TESTLOG_FILE"
+
+// verboseMode gates printing cache invalidation reasons, set from -verbose.
+var verboseMode bool
+
+// responseManifest is the JSON document stored in a response folder's
+// hash.cache: the response/test file hashes plus every env var and file the
+// test run actually observed through the testlog helpers.
+type responseManifest struct {
+	ResponseHash string            `json:"response_hash"`
+	TestHash     string            `json:"test_hash"`
+	Files        map[string]string `json:"files,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+}
+
+// observedInput is one "FILE <path> <hash>" or "ENV <name> <hash>" line
+// appended to testLogFile by the testlog helpers during a test run.
+type observedInput struct {
+	kind string
+	name string
+	hash string
+}
+
+func parseTestLog(path string) []observedInput {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var inputs []observedInput
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		inputs = append(inputs, observedInput{kind: fields[0], name: fields[1], hash: fields[2]})
+	}
+	return inputs
+}
+
+// buildResponseManifest hashes the response and test files and folds in
+// whatever the test observed reading, per testLogFile.
+func buildResponseManifest(responseFile, testFile, testLogFile string) (responseManifest, error) {
+	responseHash, err := calculateFileHash(responseFile)
+	if err != nil {
+		return responseManifest{}, fmt.Errorf("error hashing response file: %w", err)
+	}
+	testHash, err := calculateFileHash(testFile)
+	if err != nil {
+		return responseManifest{}, fmt.Errorf("error hashing test file: %w", err)
+	}
+
+	m := responseManifest{ResponseHash: responseHash, TestHash: testHash}
+	for _, in := range parseTestLog(testLogFile) {
+		switch in.kind {
+		case "FILE":
+			if m.Files == nil {
+				m.Files = map[string]string{}
+			}
+			m.Files[in.name] = in.hash
+		case "ENV":
+			if m.Env == nil {
+				m.Env = map[string]string{}
+			}
+			m.Env[in.name] = in.hash
+		}
+	}
+	return m, nil
+}
+
+func loadResponseManifest(manifestFile string) (responseManifest, bool) {
+	data, err := os.ReadFile(manifestFile)
+	if err != nil {
+		return responseManifest{}, false
+	}
+	var m responseManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return responseManifest{}, false
+	}
+	return m, true
+}
+
+func writeResponseManifest(manifestFile string, m responseManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling manifest: %w", err)
+	}
+	return os.WriteFile(manifestFile, data, 0644)
+}
+
+// manifestInvalidationReason recomputes every input recorded in the cached
+// manifest (without re-running the test) and returns a human-readable
+// description of the first one that no longer matches, or "" if the cached
+// manifest is still fully valid.
+func manifestInvalidationReason(manifestFile, responseFile, testFile string) (string, bool) {
+	cached, ok := loadResponseManifest(manifestFile)
+	if !ok {
+		return "no cached manifest", false
+	}
+
+	responseHash, err := calculateFileHash(responseFile)
+	if err != nil || responseHash != cached.ResponseHash {
+		return "response file changed", false
+	}
+	testHash, err := calculateFileHash(testFile)
+	if err != nil || testHash != cached.TestHash {
+		return "test file changed", false
+	}
+
+	names := make([]string, 0, len(cached.Files))
+	for name := range cached.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		hash, err := calculateFileHash(name)
+		if err != nil || hash != cached.Files[name] {
+			return fmt.Sprintf("cache miss: %s changed", name), false
+		}
+	}
+
+	envNames := make([]string, 0, len(cached.Env))
+	for name := range cached.Env {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		sum := sha256.Sum256([]byte(os.Getenv(name)))
+		if hex.EncodeToString(sum[:]) != cached.Env[name] {
+			return fmt.Sprintf("cache miss: env %s changed", name), false
+		}
+	}
+
+	return "", true
+}
+
+// runGoTestManifestCached runs dispatch (which is handed a testlog file path
+// to export to the test subprocess) unless the response folder's hash.cache
+// manifest shows every previously observed input is still unchanged, in
+// which case the cached result.txt is reused without running anything.
+func runGoTestManifestCached(responseFile, testFile, workDir, responseFolder, responseName string, dispatch func(testLogFile string) (TestResult, error)) TestResult {
+	manifestFile := filepath.Join(responseFolder, "hash.cache")
+
+	if reason, valid := manifestInvalidationReason(manifestFile, responseFile, testFile); valid {
+		if cachedResult, err := loadCachedResult(responseFolder); err == nil {
+			fmt.Printf("🚀 %s - Using cached result (manifest unchanged)\n", responseName)
+			return cachedResult
+		}
+	} else if verboseMode {
+		fmt.Printf("%s cache miss: %s\n", responseName, reason)
+	}
+
+	testLogFile := filepath.Join(workDir, fmt.Sprintf(".testlog_%s", responseName))
+	os.Remove(testLogFile)
+	defer os.Remove(testLogFile)
+
+	result, err := dispatch(testLogFile)
+	if err != nil {
+		// A timed-out dispatch already fills in Output (and TimedOut) with
+		// something more useful than this generic message; only fall back
+		// to it when dispatch didn't leave anything behind.
+		if result.Output == "" {
+			result.Output = fmt.Sprintf("Failed to run test: %v", err)
+		}
+		return result
+	}
+
+	resultFile := filepath.Join(responseFolder, "result.txt")
+	if err := os.WriteFile(resultFile, []byte(result.Output), 0644); err != nil {
+		fmt.Printf("Warning: Could not write result file for %s: %v\n", responseName, err)
+	}
+
+	manifest, err := buildResponseManifest(responseFile, testFile, testLogFile)
+	if err != nil {
+		fmt.Printf("Warning: could not build cache manifest for %s: %v\n", responseName, err)
+		return result
+	}
+	if err := writeResponseManifest(manifestFile, manifest); err != nil {
+		fmt.Printf("Warning: Could not write hash cache for %s: %v\n", responseName, err)
+	}
+
+	return result
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parallelWorker owns a scratch subdirectory under the task's workDir so its
+// temp_*.go, coverage.out, staged test file and vendored testlog files never
+// collide with another worker's files. mu serializes the worker's own
+// terminal-start -> test-run -> screenshot sequence, since the worker's
+// scratch files are reused across jobs.
+type parallelWorker struct {
+	id      int
+	workDir string
+	mu      sync.Mutex
+}
+
+// newParallelWorker creates worker id's scratch directory, vendors the
+// testlog package into it (see testlog_vendor.go) so the manifest cache can
+// observe env vars and files the test reads, and stages an instrumented
+// copy of the shared test file into it so `go test` can run entirely out of
+// the worker's own directory.
+func newParallelWorker(id int, rootWorkDir, testFile string) (*parallelWorker, error) {
+	workDir := filepath.Join(rootWorkDir, fmt.Sprintf(".worker%d", id))
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create scratch dir for worker %d: %w", id, err)
+	}
+	if err := vendorTestlog(workDir); err != nil {
+		return nil, fmt.Errorf("failed to vendor testlog for worker %d: %w", id, err)
+	}
+
+	content, err := os.ReadFile(testFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test file for worker %d: %w", id, err)
+	}
+	staged := filepath.Join(workDir, filepath.Base(testFile))
+	if err := os.WriteFile(staged, instrumentTestFile(content), 0644); err != nil {
+		return nil, fmt.Errorf("failed to stage test file for worker %d: %w", id, err)
+	}
+
+	return &parallelWorker{id: id, workDir: workDir}, nil
+}
+
+// run executes a single response's terminal-start -> test-run -> screenshot
+// sequence inside the worker's own scratch directory.
+func (w *parallelWorker) run(responseFile, testFile, responseName, testLogFile string) (TestResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stagedTestFile := filepath.Join(w.workDir, filepath.Base(testFile))
+	return runTestSequence(responseFile, stagedTestFile, w.workDir, responseName, testLogFile)
+}
+
+// reportResult prints and notifies for a single finished test result. Every
+// worker in RunAll calls it, so reporting behaves identically regardless of
+// -parallel.
+func reportResult(result TestResult) {
+	isTimedOut := strings.Contains(result.Output, "TIMED OUT")
+
+	if result.Success {
+		if result.Cached {
+			fmt.Printf("✅ %s PASSED! (cached)\n", result.Name)
+		} else {
+			fmt.Printf("✅ %s PASSED!\n", result.Name)
+			sendNotification("Go Test Passed! 🎉", fmt.Sprintf("%s passed all tests!", result.Name))
+		}
+	} else if isTimedOut {
+		fmt.Printf("⏰ %s TIMED OUT (failed)\n", result.Name)
+	} else {
+		fmt.Printf("❌ %s FAILED\n", result.Name)
+	}
+}
+
+// runGoTestWithWorker applies runGoTestManifestCached's manifest-cache check
+// and runs the terminal/test/screenshot sequence inside the given worker's
+// scratch directory so concurrent workers never collide.
+func runGoTestWithWorker(responseFile, testFile string, w *parallelWorker) TestResult {
+	responseFolder := filepath.Dir(responseFile)
+	responseName := filepath.Base(responseFile)
+	responseName = strings.TrimSuffix(responseName, ".go")
+
+	renamedResponseName, err := renameResponse(responseName)
+	if err != nil {
+		fmt.Printf("Cannot rename reponse; using old name %s: %v\n", responseName, err)
+		renamedResponseName = responseName
+	}
+
+	return runGoTestManifestCached(responseFile, testFile, w.workDir, responseFolder, responseName,
+		func(testLogFile string) (TestResult, error) {
+			start := time.Now()
+			result, err := w.run(responseFile, testFile, renamedResponseName, testLogFile)
+			result.DurationMs = time.Since(start).Milliseconds()
+			if strings.Contains(result.Output, "TIMED OUT") {
+				result.TimedOut = true
+			}
+			return result, err
+		})
+}
+
+// forceRebuild bypasses the .bcb dependency check in RunAll, set from the
+// -force flag in main().
+var forceRebuild bool
+
+// responseDisplayName returns the name a response will be reported under
+// (its renamed response_A..response_Z form when available), matching what
+// ends up in TestResult.Name.
+func responseDisplayName(responseFile string) string {
+	name := strings.TrimSuffix(filepath.Base(responseFile), ".go")
+	if renamed, err := renameResponse(name); err == nil {
+		return renamed
+	}
+	return name
+}
+
+// RunAll runs the test for every response file, using a bounded pool of
+// `parallel` workers (each with its own scratch subdirectory, vendored
+// testlog package, and instrumented test file copy — see newParallelWorker)
+// so multiple responses can be tested concurrently without their terminal/
+// screenshot sequences colliding. parallel<=1 still runs one
+// response at a time, but through the same single worker so every run goes
+// through the same vendored/instrumented path. Each response is first
+// checked against its .bcb/<name>.dep log (see depcache.go); unless
+// forceRebuild is set, an unchanged target is restored without dispatching a
+// worker at all. Results are always returned sorted by name so
+// result.txt/results.json stay deterministic regardless of -parallel.
+func RunAll(responses []string, testFile, workDir string, parallel int) []TestResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+	if parallel > len(responses) {
+		parallel = len(responses)
+	}
+
+	names := make([]string, len(responses))
+	for i, responseFile := range responses {
+		names[i] = responseDisplayName(responseFile)
+	}
+	progress := newProgressRenderer(names, !headlessMode && !interactiveMode)
+
+	runTracked := func(responseFile string, exec func() TestResult) TestResult {
+		name := responseDisplayName(responseFile)
+		progress.set(name, statusRunning)
+		result := runResponseTracked(responseFile, testFile, workDir, forceRebuild, exec)
+		progress.set(name, statusForResult(result))
+		return result
+	}
+
+	jobs := make(chan string)
+	resultsCh := make(chan TestResult, len(responses))
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		worker, err := newParallelWorker(i, workDir, testFile)
+		if err != nil {
+			fmt.Printf("Warning: could not start worker %d: %v\n", i, err)
+			continue
+		}
+
+		wg.Add(1)
+		go func(w *parallelWorker) {
+			defer wg.Done()
+			for responseFile := range jobs {
+				result := runTracked(responseFile, func() TestResult {
+					return runGoTestWithWorker(responseFile, testFile, w)
+				})
+				reportResult(result)
+				resultsCh <- result
+			}
+		}(worker)
+	}
+
+	go func() {
+		for _, responseFile := range responses {
+			jobs <- responseFile
+		}
+		close(jobs)
+	}()
+
+	wg.Wait()
+	close(resultsCh)
+
+	results := make([]TestResult, 0, len(responses))
+	for result := range resultsCh {
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
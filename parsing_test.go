@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenameResponseBoundaries(t *testing.T) {
+	cases := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"response1", "response_A", false},
+		{"response26", "response_Z", false},
+		{"response0", "", true},
+		{"response27", "", true},
+		{"notaresponse", "", true},
+	}
+
+	for _, c := range cases {
+		got, err := renameResponse(c.name)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("renameResponse(%q) = %q, want error", c.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("renameResponse(%q) unexpected error: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("renameResponse(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestParseGobcoCoverageZeroConditions(t *testing.T) {
+	branchCoverage, report, conditions := parseGobcoCoverage("Condition coverage: 0/0\n")
+	if branchCoverage != 0 {
+		t.Errorf("branch coverage for 0/0 conditions = %v, want 0 (no divide-by-zero)", branchCoverage)
+	}
+	if !strings.Contains(report, "Branch Coverage: 0.0%") {
+		t.Errorf("report = %q, want it to note 0.0%% coverage", report)
+	}
+	if len(conditions) != 0 {
+		t.Errorf("conditions = %v, want none for a line with no per-condition detail", conditions)
+	}
+}
+
+func TestParseGobcoCoverageMalformedLine(t *testing.T) {
+	branchCoverage, report, conditions := parseGobcoCoverage("Condition coverage: not-a-fraction\n")
+	if branchCoverage != 0 {
+		t.Errorf("branch coverage for malformed line = %v, want 0", branchCoverage)
+	}
+	if report != "" {
+		t.Errorf("report = %q, want empty for a line with no parseable fraction", report)
+	}
+	if len(conditions) != 0 {
+		t.Errorf("conditions = %v, want none", conditions)
+	}
+}
+
+func TestParseGobcoCoverageConditionDetail(t *testing.T) {
+	output := `main.go:12:5: condition "x > 0" is true 3 times and false 0 times`
+	_, report, conditions := parseGobcoCoverage(output)
+	if !strings.Contains(report, "condition") {
+		t.Errorf("report = %q, want the raw condition line preserved", report)
+	}
+	if len(conditions) != 1 {
+		t.Fatalf("conditions = %v, want exactly one parsed Condition", conditions)
+	}
+	got := conditions[0]
+	want := Condition{File: "main.go", Line: 12, Expr: "x > 0", TrueCount: 3, FalseCount: 0}
+	if got != want {
+		t.Errorf("conditions[0] = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseCoverageReportNoMatch(t *testing.T) {
+	if got := parseCoverageReport("ok  \tsome/pkg\t0.123s"); got != 0.0 {
+		t.Errorf("parseCoverageReport with no coverage line = %v, want 0", got)
+	}
+}
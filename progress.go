@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// responseStatus is the lifecycle state of one response in the progress
+// renderer below.
+type responseStatus string
+
+const (
+	statusQueued   responseStatus = "queued"
+	statusRunning  responseStatus = "running"
+	statusPassed   responseStatus = "passed"
+	statusFailed   responseStatus = "failed"
+	statusTimedOut responseStatus = "timed-out"
+	statusCached   responseStatus = "cached"
+)
+
+// progressRenderer draws a multi-line, updated-in-place status table: one
+// line per response plus running totals and elapsed time, redrawn via a
+// "move cursor up" escape sequence the way restic's stdio backup progress
+// interleaves live status with command output.
+type progressRenderer struct {
+	mu        sync.Mutex
+	order     []string
+	status    map[string]responseStatus
+	start     time.Time
+	lastLines int
+	enabled   bool
+}
+
+// newProgressRenderer creates a renderer for responseNames, all initially
+// queued. enabled gates whether render() actually writes anything, so
+// callers can construct one unconditionally and only pay for it when it's
+// going to be shown (i.e. not in -headless or -interactive mode).
+func newProgressRenderer(responseNames []string, enabled bool) *progressRenderer {
+	status := make(map[string]responseStatus, len(responseNames))
+	for _, name := range responseNames {
+		status[name] = statusQueued
+	}
+	return &progressRenderer{
+		order:   append([]string(nil), responseNames...),
+		status:  status,
+		start:   time.Now(),
+		enabled: enabled,
+	}
+}
+
+// set updates name's status and redraws the table.
+func (p *progressRenderer) set(name string, status responseStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status[name] = status
+	p.render()
+}
+
+// render rewrites the whole status block in place so repeated updates don't
+// scroll the terminal. Caller must hold p.mu.
+func (p *progressRenderer) render() {
+	if !p.enabled {
+		return
+	}
+
+	if p.lastLines > 0 {
+		fmt.Printf("\033[%dA", p.lastLines)
+	}
+
+	var passed, failed, timedOut, cached, running, done int
+	lines := make([]string, 0, len(p.order))
+	for _, name := range p.order {
+		s := p.status[name]
+		switch s {
+		case statusPassed:
+			passed++
+			done++
+		case statusCached:
+			passed++
+			cached++
+			done++
+		case statusFailed:
+			failed++
+			done++
+		case statusTimedOut:
+			failed++
+			timedOut++
+			done++
+		case statusRunning:
+			running++
+		}
+		lines = append(lines, fmt.Sprintf("\033[K  %-24s %s", name, s))
+	}
+
+	elapsed := time.Since(p.start).Round(time.Second)
+	summary := fmt.Sprintf("\033[K%d/%d done (%d running, %d passed, %d failed, %d timed out, %d cached) - %s",
+		done, len(p.order), running, passed, failed, timedOut, cached, elapsed)
+
+	fmt.Println(strings.Join(lines, "\n"))
+	fmt.Println(summary)
+
+	p.lastLines = len(lines) + 1
+}
+
+// statusForResult classifies a finished TestResult for the progress table.
+func statusForResult(result TestResult) responseStatus {
+	switch {
+	case strings.Contains(result.Output, "TIMED OUT"):
+		return statusTimedOut
+	case result.Success && result.Cached:
+		return statusCached
+	case result.Success:
+		return statusPassed
+	default:
+		return statusFailed
+	}
+}
@@ -0,0 +1,118 @@
+// Package report renders a finished run's per-response results as
+// machine-readable JSON and JUnit XML, so CI dashboards can consume them
+// without parsing result.txt. It deliberately doesn't import package main's
+// TestResult/MainCoverageResult (a main package can't be imported anyway);
+// callers translate those into the Response/MainCoverage values below.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+)
+
+// Condition is one gobco-reported branch condition.
+type Condition struct {
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Expr       string `json:"expr"`
+	TrueCount  int    `json:"true_count"`
+	FalseCount int    `json:"false_count"`
+}
+
+// Response is one response's result, in the shape CI tooling consumes.
+//
+// ConditionDetails is carried on Response for schema symmetry with the
+// rest of the run, but gobco's branch analysis runs once against main.go,
+// not per response, so it's always empty here; the real data lives on
+// Document.MainCoverage.ConditionDetails.
+type Response struct {
+	Name             string      `json:"name"`
+	Status           string      `json:"status"`
+	DurationMs       int64       `json:"duration_ms"`
+	Cached           bool        `json:"cached"`
+	TimedOut         bool        `json:"timed_out"`
+	Stdout           string      `json:"stdout"`
+	Stderr           string      `json:"stderr"`
+	LineCoverage     float64     `json:"line_coverage"`
+	BranchCoverage   float64     `json:"branch_coverage"`
+	ConditionDetails []Condition `json:"condition_details,omitempty"`
+}
+
+// MainCoverage is the task-wide main.go coverage analysis.
+type MainCoverage struct {
+	LineCoverage     float64     `json:"line_coverage"`
+	BranchCoverage   float64     `json:"branch_coverage"`
+	ConditionDetails []Condition `json:"condition_details,omitempty"`
+}
+
+// Document is the top-level shape written by WriteJSON.
+type Document struct {
+	Responses    []Response    `json:"responses"`
+	MainCoverage *MainCoverage `json:"main_coverage,omitempty"`
+}
+
+// WriteJSON writes responses (and, if non-nil, mainCoverage) to path as a
+// single indented JSON document.
+func WriteJSON(path string, responses []Response, mainCoverage *MainCoverage) error {
+	data, err := json.MarshalIndent(Document{Responses: responses, MainCoverage: mainCoverage}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("report: marshaling json: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// junitTestSuite/junitTestCase/junitFailure mirror the subset of the JUnit
+// XML schema CI dashboards (Jenkins, GitHub Actions, GitLab) actually read.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      float64         `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes responses to path as a single JUnit testsuite named
+// suiteName, with one testcase per response. A response whose Status isn't
+// "passed" gets a <failure> element so CI dashboards flag it.
+func WriteJUnit(path, suiteName string, responses []Response) error {
+	suite := junitTestSuite{Name: suiteName}
+
+	for _, r := range responses {
+		tc := junitTestCase{
+			Name:      r.Name,
+			Time:      float64(r.DurationMs) / 1000,
+			SystemOut: r.Stdout,
+			SystemErr: r.Stderr,
+		}
+		if r.Status != "passed" {
+			tc.Failure = &junitFailure{Message: r.Status, Text: r.Stdout + r.Stderr}
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Time += tc.Time
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return fmt.Errorf("report: marshaling junit xml: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+	return os.WriteFile(path, data, 0644)
+}
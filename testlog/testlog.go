@@ -0,0 +1,75 @@
+// Package testlog lets a response's own test code opt into the runner's
+// content-addressed cache: wrapping os.Getenv/os.Open/os.Stat with these
+// helpers records exactly which env vars and files the test actually
+// consulted, so the runner (see manifestcache.go in the root package) can
+// invalidate a cached pass the moment one of them changes, instead of only
+// on response/test file edits.
+package testlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// envVar names the environment variable the runner exports with the path to
+// append observations to. It's a no-op when unset, so test code can call
+// these helpers unconditionally even outside the runner.
+const envVar = "BCB_TESTLOG_FILE"
+
+var mu sync.Mutex
+
+func logFile() (string, bool) {
+	path := os.Getenv(envVar)
+	return path, path != ""
+}
+
+func record(kind, name string, sum [sha256.Size]byte) {
+	path, ok := logFile()
+	if !ok {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s %s %s\n", kind, name, hex.EncodeToString(sum[:]))
+}
+
+// Getenv wraps os.Getenv, recording the variable's name and a hash of its
+// value so the runner notices if it changes between runs.
+func Getenv(name string) string {
+	value := os.Getenv(name)
+	record("ENV", name, sha256.Sum256([]byte(value)))
+	return value
+}
+
+// Open wraps os.Open, recording path and a hash of its contents at open
+// time.
+func Open(path string) (*os.File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	record("FILE", path, sha256.Sum256(data))
+	return os.Open(path)
+}
+
+// Stat wraps os.Stat, recording path and a hash of its contents so a test
+// that only checks for existence/metadata still invalidates the cache when
+// the file's content changes.
+func Stat(path string) (os.FileInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return os.Stat(path)
+	}
+	record("FILE", path, sha256.Sum256(data))
+	return os.Stat(path)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// testlogSource is the testlog package's own source (see testlog/testlog.go
+// for what it actually does), embedded into this binary so vendorTestlog
+// can write a working copy into a response's compile directory: each
+// response's go test runs as a bare file list with no go.mod of its own, so
+// there's no other way for it to resolve an import of the real
+// bigcodebench-auto/testlog package.
+//
+//go:embed testlog/testlog.go
+var testlogSource []byte
+
+// testlogModule is the module name the vendored go.mod and shim use. It's
+// unrelated to this binary's own module path on purpose: the vendored copy
+// lives in its own throwaway module rooted at the worker's scratch
+// directory, not as part of this repository's module.
+const testlogModule = "bcbtask"
+
+// testlogGoMod is the minimal module file that makes go test treat a
+// worker's scratch directory as a module so "bcbtask/testlog" resolves.
+const testlogGoMod = "module " + testlogModule + "\n\ngo 1.21\n"
+
+// testlogShim is a small package-main file vendored alongside go.mod and
+// testlog/testlog.go. instrumentTestFile rewrites a response's test file to
+// call testlogGetenv/testlogOpen/testlogStat instead of os.Getenv/os.Open/
+// os.Stat; those three functions live here rather than being imported
+// directly by the (otherwise untouched) test file, so instrumentation never
+// has to edit the test file's own import block.
+const testlogShim = `package main
+
+import (
+	"os"
+
+	"` + testlogModule + `/testlog"
+)
+
+func testlogGetenv(name string) string            { return testlog.Getenv(name) }
+func testlogOpen(path string) (*os.File, error)   { return testlog.Open(path) }
+func testlogStat(path string) (os.FileInfo, error) { return testlog.Stat(path) }
+`
+
+// vendorTestlog writes go.mod, the vendored testlog package, and the shim
+// that bridges to it into dir, so a response's go test compiled there can
+// observe the env vars and files its test code reads. Safe to call every
+// time a worker directory is (re)used; it just overwrites the same files.
+func vendorTestlog(dir string) error {
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(testlogGoMod), 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "testlog_shim.go"), []byte(testlogShim), 0644); err != nil {
+		return err
+	}
+	testlogDir := filepath.Join(dir, "testlog")
+	if err := os.MkdirAll(testlogDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(testlogDir, "testlog.go"), testlogSource, 0644)
+}
+
+// instrumentTestFile rewrites a test file's direct os.Getenv/os.Open/
+// os.Stat calls to go through the vendored shim instead, so the manifest
+// cache notices when the test's own env/file reads change. This is a plain
+// textual substitution (like modifyPackageToMain's package-line rewrite in
+// main.go), not an AST rewrite: it only catches the
+// literal call forms below, so a test that aliases the os import or builds
+// the call dynamically won't be instrumented.
+func instrumentTestFile(content []byte) []byte {
+	s := string(content)
+	s = strings.ReplaceAll(s, "os.Getenv(", "testlogGetenv(")
+	s = strings.ReplaceAll(s, "os.Open(", "testlogOpen(")
+	s = strings.ReplaceAll(s, "os.Stat(", "testlogStat(")
+	return []byte(s)
+}
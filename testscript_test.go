@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rogpeppe/go-internal/testscript"
+)
+
+// TestMain registers this binary's own run() as the "bcb" self-exec command
+// (the standard testscript trick), so testdata/script/*.txtar scripts drive
+// the real CLI end to end instead of a stand-in. It also registers a fake
+// "gobco" command so scripts can exercise parseGobcoCoverage's edge cases
+// through the real CLI without the real github.com/rillig/gobco tool being
+// installed.
+func TestMain(m *testing.M) {
+	os.Exit(testscript.RunMain(m, map[string]func() int{
+		"bcb":   run,
+		"gobco": fakeGobco,
+	}))
+}
+
+// fakeGobco stands in for the real gobco binary: it prints GOBCO_OUTPUT (or
+// a default all-conditions-covered line, so scripts that don't care about
+// branch coverage aren't affected) to stdout and exits 0.
+func fakeGobco() int {
+	output := os.Getenv("GOBCO_OUTPUT")
+	if output == "" {
+		output = "Condition coverage: 1/1\n"
+	}
+	fmt.Print(output)
+	return 0
+}
+
+// TestScripts runs every testdata/script/*.txtar golden script.
+func TestScripts(t *testing.T) {
+	testscript.Run(t, testscript.Params{
+		Dir: "testdata/script",
+		Cmds: map[string]func(ts *testscript.TestScript, neg bool, args []string){
+			"bcb-run":               cmdBcbRun,
+			"bcb-mutate-response":   cmdBcbMutateResponse,
+			"bcb-expect-cached":     cmdBcbExpectCached,
+			"bcb-expect-not-cached": cmdBcbExpectNotCached,
+		},
+	})
+}
+
+// cmdBcbRun runs `bcb -headless $@` in the script's current directory,
+// failing the script if the exit code doesn't match neg.
+func cmdBcbRun(ts *testscript.TestScript, neg bool, args []string) {
+	err := ts.Exec("bcb", append([]string{"-headless"}, args...)...)
+	if neg {
+		if err == nil {
+			ts.Fatalf("bcb-run: expected failure, got success")
+		}
+		return
+	}
+	ts.Check(err)
+}
+
+// cmdBcbMutateResponse appends a trailing comment to the named response's
+// .go file (resolved via the script's "env" file, same as the CLI itself),
+// giving its content (and thus its cache manifest hash) a deterministic,
+// script-visible change without needing real source edits.
+func cmdBcbMutateResponse(ts *testscript.TestScript, neg bool, args []string) {
+	if len(args) != 1 {
+		ts.Fatalf("usage: bcb-mutate-response <responseN>")
+	}
+	name := args[0]
+	taskID, err := readEnvFile(ts.MkAbs("env"))
+	ts.Check(err)
+	path := ts.MkAbs(fmt.Sprintf("%s/%s/%s.go", taskID, name, name))
+	data, err := os.ReadFile(path)
+	ts.Check(err)
+	mutated := string(data) + "\n// bcb-mutate-response\n"
+	ts.Check(os.WriteFile(path, []byte(mutated), 0644))
+}
+
+// cmdBcbExpectCached fails the script unless stdout from the last bcb-run
+// shows a cache hit for the named response, given by its reported
+// (renamed) name such as response_A.
+func cmdBcbExpectCached(ts *testscript.TestScript, neg bool, args []string) {
+	expectCacheState(ts, args, true)
+}
+
+// cmdBcbExpectNotCached is cmdBcbExpectCached's inverse, for asserting a
+// mutation actually busted the cache.
+func cmdBcbExpectNotCached(ts *testscript.TestScript, neg bool, args []string) {
+	expectCacheState(ts, args, false)
+}
+
+func expectCacheState(ts *testscript.TestScript, args []string, wantCached bool) {
+	if len(args) != 1 {
+		ts.Fatalf("usage: bcb-expect-cached|bcb-expect-not-cached <response_X>")
+	}
+	stdout := ts.ReadFile("stdout")
+	cachedLine := fmt.Sprintf("%s PASSED! (cached)", args[0])
+	isCached := strings.Contains(stdout, cachedLine)
+	if isCached != wantCached {
+		ts.Fatalf("bcb-expect-cached %s: cached=%v, want %v\nstdout:\n%s", args[0], isCached, wantCached, stdout)
+	}
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killProcessTree kills cmd and its children by signalling its process
+// group; see windows.go for the Windows equivalent.
+func killProcessTree(cmd *exec.Cmd) {
+	killProcessGroupUnix(cmd)
+}
+
+// setProcessGroup marks cmd to run in its own process group so that the
+// whole subtree (the compiled test binary, gobco, any helpers it spawns)
+// can be killed with a single signal to -pid instead of leaking orphans.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroupUnix signals cmd's process group with SIGTERM, gives it up
+// to ~2s to exit, then escalates to SIGKILL for anything still alive.
+//
+// It polls for exit with syscall.Kill(pgid, 0) rather than cmd.Wait(): most
+// callers (runHeadlessTest, runHeadlessMainTest) have already called Wait
+// (or CombinedOutput, which calls it internally) by the time they decide
+// the command timed out and reach here, and a *exec.Cmd can only be waited
+// on once — a second Wait returns "exec: Wait was already called" almost
+// instantly instead of blocking, which used to make the SIGKILL escalation
+// below never fire.
+func killProcessGroupUnix(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	pgid := cmd.Process.Pid
+
+	if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+		fmt.Printf("Warning: SIGTERM to process group %d failed: %v\n", pgid, err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		// Signal 0 sends nothing but still reports ESRCH once nothing in
+		// the group answers to pgid anymore.
+		if err := syscall.Kill(-pgid, syscall.Signal(0)); err != nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil {
+		fmt.Printf("Warning: SIGKILL to process group %d failed: %v\n", pgid, err)
+	}
+}
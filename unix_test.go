@@ -0,0 +1,38 @@
+//go:build !windows
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestKillProcessGroupUnix verifies that killProcessTree reaps an entire
+// process group, not just the immediate child: a wrapper shell spawns
+// `sleep 30`, and after killProcessTree returns neither process should still
+// be signalable.
+func TestKillProcessGroupUnix(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 30 & wait")
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start wrapper script: %v", err)
+	}
+	pgid := cmd.Process.Pid
+
+	// Reap cmd in the background: a signalled-but-unwaited process is a
+	// zombie, and a zombie still answers kill(pid, 0) successfully — only
+	// this test, as cmd's parent, can turn that into ESRCH by waiting it.
+	go cmd.Wait()
+
+	// Give the shell a moment to fork the sleep child.
+	time.Sleep(200 * time.Millisecond)
+
+	killProcessTree(cmd)
+
+	if err := syscall.Kill(-pgid, syscall.Signal(0)); err == nil {
+		t.Fatalf("process group %d is still alive after killProcessTree", pgid)
+	}
+}
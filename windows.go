@@ -0,0 +1,28 @@
+//go:build windows
+
+package main
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// killProcessTree kills cmd and its children via taskkill; see unix.go for
+// the POSIX equivalent.
+func killProcessTree(cmd *exec.Cmd) {
+	killProcessGroupWindows(cmd)
+}
+
+// setProcessGroup is a no-op on Windows; killProcessGroupWindows uses
+// `taskkill /T` to terminate the whole process tree instead of relying on a
+// POSIX process group.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroupWindows terminates cmd and its children via taskkill.
+func killProcessGroupWindows(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	killCmd := exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(cmd.Process.Pid))
+	killCmd.Run()
+}